@@ -0,0 +1,33 @@
+package ishare
+
+import (
+	"crypto/x509"
+	"sync"
+)
+
+// caCertificateCache keeps the actual x509.Certificate for a trusted CA
+// fingerprint around once it has been seen, i.e. when it matched on the
+// fingerprint fast path of IsTrusted or was loaded from the configured CA
+// bundle. isTrustedChain builds its CertPool from it, and it lets
+// fingerprint-only configuration (see ISHARE_TRUSTED_FINGERPRINTS_LIST)
+// be reported with a human
+// readable subject DN and notAfter once the real certificate is known.
+type caCertificateCache struct {
+	lock  sync.RWMutex
+	certs map[string]*x509.Certificate
+}
+
+var trustedCaCertificateCache = &caCertificateCache{certs: map[string]*x509.Certificate{}}
+
+func (c *caCertificateCache) get(fingerprint string) (*x509.Certificate, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	cert, found := c.certs[fingerprint]
+	return cert, found
+}
+
+func (c *caCertificateCache) put(fingerprint string, cert *x509.Certificate) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.certs[fingerprint] = cert
+}
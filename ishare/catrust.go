@@ -0,0 +1,75 @@
+package ishare
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// ISHARE_TRUSTED_CA_CERTIFICATES_PATH points to a PEM bundle of the
+// actual CA certificates behind ISHARE_TRUSTED_FINGERPRINTS_LIST and the
+// satellite's trusted_list entries. It is what lets chain verification
+// (see isTrustedChain) and the startup/debug trust summary work from
+// day one, instead of only after a matching root has incidentally been
+// presented on the fingerprint fast path.
+const TrustedCaCertificatesPathEnvVar = "ISHARE_TRUSTED_CA_CERTIFICATES_PATH"
+
+// resolveTrustedCaCertificates loads the configured CA certificate
+// bundle and caches every certificate whose fingerprint is currently
+// trusted, keyed by that fingerprint. Certificates outside the current
+// trust list are skipped, and their absence is only logged at trace
+// level, since the bundle is expected to be a superset (e.g. it also
+// carries CAs that were trusted in the past).
+func (icr IShareTrustedParticipantRepository) resolveTrustedCaCertificates() {
+	bundlePath := os.Getenv(TrustedCaCertificatesPathEnvVar)
+	if bundlePath == "" {
+		logger.Tracef("%s is not configured, chain verification will only see CAs presented directly.", TrustedCaCertificatesPathEnvVar)
+		return
+	}
+
+	certs, err := loadCertificateBundle(bundlePath)
+	if err != nil {
+		logger.Warnf("Was not able to load the trusted CA certificate bundle at %s. Err: %v", bundlePath, err)
+		return
+	}
+
+	for _, cert := range certs {
+		fingerprint := buildCertificateFingerprint(cert)
+		if !contains(icr.trustedFingerprints, fingerprint) {
+			logger.Tracef("Certificate %s from the bundle is not currently trusted, skipping.", cert.Subject)
+			continue
+		}
+		trustedCaCertificateCache.put(fingerprint, cert)
+	}
+}
+
+// loadCertificateBundle parses every PEM-encoded certificate in path.
+func loadCertificateBundle(path string) ([]*x509.Certificate, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to read %s: %w", path, err)
+	}
+
+	var certs []*x509.Certificate
+	rest := pemBytes
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("was not able to parse a certificate in %s: %w", path, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s does not contain any PEM encoded certificate", path)
+	}
+	return certs, nil
+}
@@ -0,0 +1,83 @@
+package ishare
+
+import (
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestBundle(t *testing.T, certs ...[]byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bundle.pem")
+	var pemBytes []byte
+	for _, der := range certs {
+		pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	if err := os.WriteFile(path, pemBytes, 0600); err != nil {
+		t.Fatalf("was not able to write the test bundle: %v", err)
+	}
+	return path
+}
+
+func TestLoadCertificateBundle(t *testing.T) {
+	_, cert := generateSelfSignedWithSubject(t, pkix.Name{CommonName: "Test CA"}, true)
+	path := writeTestBundle(t, cert.Raw)
+
+	certs, err := loadCertificateBundle(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(certs) != 1 {
+		t.Fatalf("expected 1 certificate, got %d", len(certs))
+	}
+	if certs[0].Subject.CommonName != "Test CA" {
+		t.Errorf("expected the parsed certificate's subject to be preserved, got %q", certs[0].Subject.CommonName)
+	}
+}
+
+func TestLoadCertificateBundleEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("was not able to write the test file: %v", err)
+	}
+	if _, err := loadCertificateBundle(path); err == nil {
+		t.Error("expected an error for a bundle with no PEM-encoded certificate")
+	}
+}
+
+func TestLoadCertificateBundleMissingFile(t *testing.T) {
+	if _, err := loadCertificateBundle(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("expected an error for a missing bundle file")
+	}
+}
+
+func TestResolveTrustedCaCertificatesOnlyCachesTrustedFingerprints(t *testing.T) {
+	_, trusted := generateSelfSignedWithSubject(t, pkix.Name{CommonName: "Trusted CA"}, true)
+	_, untrusted := generateSelfSignedWithSubject(t, pkix.Name{CommonName: "Untrusted CA"}, true)
+	path := writeTestBundle(t, trusted.Raw, untrusted.Raw)
+
+	trustedFingerprint := buildCertificateFingerprint(trusted)
+	untrustedFingerprint := buildCertificateFingerprint(untrusted)
+
+	t.Setenv(TrustedCaCertificatesPathEnvVar, path)
+	repo := IShareTrustedParticipantRepository{trustedFingerprints: []string{trustedFingerprint}}
+	repo.resolveTrustedCaCertificates()
+
+	if _, found := trustedCaCertificateCache.get(trustedFingerprint); !found {
+		t.Error("expected the trusted CA certificate to be cached")
+	}
+	if _, found := trustedCaCertificateCache.get(untrustedFingerprint); found {
+		t.Error("expected the untrusted CA certificate to not be cached")
+	}
+}
+
+func TestResolveTrustedCaCertificatesWithoutConfiguredBundle(t *testing.T) {
+	t.Setenv(TrustedCaCertificatesPathEnvVar, "")
+	repo := IShareTrustedParticipantRepository{trustedFingerprints: []string{"deadbeef"}}
+	repo.resolveTrustedCaCertificates()
+	if _, found := trustedCaCertificateCache.get("deadbeef"); found {
+		t.Error("expected nothing to be cached when no bundle path is configured")
+	}
+}
@@ -0,0 +1,41 @@
+package ishare
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+)
+
+// ISHARE_DEBUG_TRUST_TOKEN guards the /debug/trust endpoint. The
+// endpoint is disabled unless this is set.
+const DebugTrustTokenEnvVar = "ISHARE_DEBUG_TRUST_TOKEN"
+
+// DebugTrustPath is the path the handler below should be registered on.
+const DebugTrustPath = "/debug/trust"
+
+// DebugTrustHandler serves the same structured trust summary logged at
+// startup, so operators can diff what a running PDP actually trusts
+// against what they configured without restarting it with DEBUG logging.
+// It is a no-op (404) unless ISHARE_DEBUG_TRUST_TOKEN is configured, and
+// requires it as a bearer token otherwise.
+func (icr IShareTrustedParticipantRepository) DebugTrustHandler(updateRateInS int) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		expectedToken := os.Getenv(DebugTrustTokenEnvVar)
+		if expectedToken == "" {
+			http.NotFound(writer, request)
+			return
+		}
+		presentedToken := request.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(presentedToken), []byte("Bearer "+expectedToken)) != 1 {
+			http.Error(writer, "invalid or missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		summary := icr.Summary(request.Context(), updateRateInS)
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(summary); err != nil {
+			logger.Warnf("Was not able to encode the trust summary response. Err: %v", err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -26,6 +27,32 @@ const SatelliteTokenPathEnvVar = "ISHARE_TRUST_ANCHOR_TOKEN_PATH"
 const SatelliteTrustedListPathEnvVar = "ISHARE_TRUST_ANCHOR_TRUSTED_LIST_PATH"
 const TrustedListUpdateRateEnvVar = "ISHARE_TRUSTED_LIST_UPDATE_RATE"
 
+// ISHARE_CLIENT_EORI is this PDP's own EORI, used as the "iss"/"sub"/
+// "client_id" of the client_assertion it presents to the satellite when
+// ISHARE_SIGNER_KEY_URI/ISHARE_SIGNER_KMS select a KeyManager-backed
+// signer instead of a caller-supplied TokenFunc.
+const ClientEoriEnvVar = "ISHARE_CLIENT_EORI"
+
+// ISHARE_TRUST_MODE selects how IsTrusted verifies a party's certificate
+// once its CA is not matched by the fingerprint fast path: "fingerprint"
+// (default) keeps the pre-existing exact-match behaviour against the
+// X5c entries returned from /party/{id}; "chain" verifies the presented
+// certificate against a CertPool of trusted CA certificates instead,
+// which tolerates intermediate rotation.
+const TrustModeEnvVar = "ISHARE_TRUST_MODE"
+
+const (
+	TrustModeFingerprint = "fingerprint"
+	TrustModeChain       = "chain"
+)
+
+func trustMode() string {
+	if os.Getenv(TrustModeEnvVar) == TrustModeChain {
+		return TrustModeChain
+	}
+	return TrustModeFingerprint
+}
+
 var satelliteURL = "https://scheme.isharetest.net"
 var satelliteId = "EU.EORI.NL000000000"
 var satelliteTokenPath = "/connect/token"
@@ -43,6 +70,7 @@ type IShareTrustedParticipantRepository struct {
 	tokenFunc             TokenFunc
 	trustedListParserFunc TrustedListParseFunc
 	partyParseFunc        PartyParseFunc
+	revocationChecker     RevocationChecker
 }
 
 func NewTrustedParticipantRepository(tokenFunc TokenFunc, trustedListParserFunc TrustedListParseFunc, partyParseFunc PartyParseFunc) *IShareTrustedParticipantRepository {
@@ -86,29 +114,69 @@ func NewTrustedParticipantRepository(tokenFunc TokenFunc, trustedListParserFunc
 
 	logger.Debugf("Using satellite %s as trust anchor.", logging.PrettyPrintObject(ar))
 	trustedParticipantRepo.satelliteAr = &ar
-	trustedParticipantRepo.tokenFunc = tokenFunc
+	trustedParticipantRepo.tokenFunc = tokenFuncOrDefault(tokenFunc)
 	trustedParticipantRepo.trustedListParserFunc = trustedListParserFunc
 	trustedParticipantRepo.partyParseFunc = partyParseFunc
+	trustedParticipantRepo.revocationChecker = NewRevocationChecker()
+
+	// Resolve the trusted CA certificates before reporting on them, so the
+	// startup summary below shows a real subject DN/notAfter instead of a
+	// bare fingerprint whenever ISHARE_TRUSTED_CA_CERTIFICATES_PATH is set.
+	trustedParticipantRepo.resolveTrustedCaCertificates()
 
 	trustedParticipantRepo.scheduleTrustedListUpdate(updateRateInS)
+	trustedParticipantRepo.Summary(context.Background(), updateRateInS)
 
 	return trustedParticipantRepo
 }
 
+// tokenFuncOrDefault returns tokenFunc unchanged if the caller supplied
+// one. Otherwise, if a KeyManager-backed signer is configured via
+// ISHARE_SIGNER_KEY_URI/ISHARE_SIGNER_KMS, it builds one with
+// NewKmsTokenFunc, so the client_assertion sent to the satellite is
+// signed with a private key resolved through the KeyManager abstraction
+// rather than requiring every caller to wire that up itself.
+func tokenFuncOrDefault(tokenFunc TokenFunc) TokenFunc {
+	if tokenFunc != nil {
+		return tokenFunc
+	}
+	keyURI := ConfiguredKeyURI()
+	if keyURI == "" {
+		return nil
+	}
+	clientEori := os.Getenv(ClientEoriEnvVar)
+	if clientEori == "" {
+		logger.Warnf("%s is configured but %s is not set, cannot build a KMS-backed token function.", SignerKeyUriEnvVar, ClientEoriEnvVar)
+		return nil
+	}
+	logger.Debugf("Signing outbound client assertions with the %s key manager.", keyURI.Scheme())
+	return NewKmsTokenFunc(keyURI, clientEori)
+}
+
 func (icr IShareTrustedParticipantRepository) scheduleTrustedListUpdate(updateRateInS int) {
 	taskScheduler := chrono.NewDefaultTaskScheduler()
 	taskScheduler.ScheduleAtFixedRate(icr.updateTrustedFingerprints, time.Duration(updateRateInS)*time.Second)
 }
 
 func (icr IShareTrustedParticipantRepository) IsTrusted(caCertificate *x509.Certificate, clientCertificate *x509.Certificate, clientId string) (isTrusted bool) {
-	// check against trusted cas
+	// fast path: exact fingerprint match against the trusted cas, kept for
+	// self-contained deployments that do not rotate intermediates.
 	certificateFingerPrint := buildCertificateFingerprint(caCertificate)
 	logger.Tracef("Checking certificate with fingerprint %s.", string(certificateFingerPrint))
 	if contains(icr.trustedFingerprints, certificateFingerPrint) {
+		trustedCaCertificateCache.put(certificateFingerPrint, caCertificate)
+		if icr.isRevoked(clientCertificate, caCertificate) {
+			logger.Infof("The certificate for %s has been revoked.", clientId)
+			return false
+		}
 		logger.Tracef("The presented certificate is trusted.")
 		return true
 	}
 
+	if trustMode() == TrustModeChain {
+		return icr.isTrustedChain(caCertificate, clientCertificate, clientId)
+	}
+
 	// ca is not listed, check just the party
 	trustedParty, err := icr.getTrustedParty(clientId)
 	if err != (model.HttpError{}) {
@@ -127,6 +195,10 @@ func (icr IShareTrustedParticipantRepository) IsTrusted(caCertificate *x509.Cert
 			return false
 		}
 		if buildCertificateFingerprint(parsedCert) == buildCertificateFingerprint(clientCertificate) {
+			if icr.isRevoked(clientCertificate, caCertificate) {
+				logger.Infof("The certificate for %s has been revoked.", clientId)
+				return false
+			}
 			logger.Tracef("The presented certificate is listed for party %s.", clientId)
 			return true
 		}
@@ -134,6 +206,58 @@ func (icr IShareTrustedParticipantRepository) IsTrusted(caCertificate *x509.Cert
 	return false
 }
 
+// isTrustedChain verifies clientCertificate against a CertPool built from
+// the CA certificates we have actually seen for the currently trusted
+// fingerprints, instead of requiring an exact match against the X5c
+// entries returned from /party/{id}. This is what lets a deployment
+// rotate intermediate certificates without having to update the party's
+// listed certificates in lockstep.
+func (icr IShareTrustedParticipantRepository) isTrustedChain(caCertificate *x509.Certificate, clientCertificate *x509.Certificate, clientId string) bool {
+	pool := x509.NewCertPool()
+	found := false
+	for _, fingerprint := range icr.trustedFingerprints {
+		cert, ok := trustedCaCertificateCache.get(fingerprint)
+		if !ok {
+			continue
+		}
+		pool.AddCert(cert)
+		found = true
+	}
+	if !found {
+		logger.Infof("No trusted CA certificate is cached yet to verify %s's chain against.", clientId)
+		return false
+	}
+
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(caCertificate)
+
+	chains, err := clientCertificate.Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		logger.Infof("Was not able to verify a trust chain for %s. Err: %v", clientId, err)
+		return false
+	}
+	if len(chains) == 0 {
+		return false
+	}
+
+	if !eoriMatchesCertificate(clientCertificate, clientId) {
+		logger.Infof("The EORI %s does not match the organizationIdentifier/serialNumber of the presented certificate.", clientId)
+		return false
+	}
+
+	if icr.isRevoked(clientCertificate, caCertificate) {
+		logger.Infof("The certificate for %s has been revoked.", clientId)
+		return false
+	}
+
+	logger.Tracef("Verified a trust chain for %s.", clientId)
+	return true
+}
+
 func (icr IShareTrustedParticipantRepository) updateTrustedFingerprints(ctx context.Context) {
 
 	logger.Tracef("Certificate is not the satellite, request the current list.")
@@ -157,9 +281,13 @@ func (icr IShareTrustedParticipantRepository) updateTrustedFingerprints(ctx cont
 	}
 	icr.trustedFingerprints = updatedFingerPrints
 	logger.Tracef("Updated trusted fingerprints to: %s", icr.trustedFingerprints)
+	icr.resolveTrustedCaCertificates()
 }
 
 func (icr IShareTrustedParticipantRepository) getTrustedParty(id string) (trustedParty *model.PartyInfo, httpErr model.HttpError) {
+	if icr.tokenFunc == nil {
+		return trustedParty, model.HttpError{Status: http.StatusInternalServerError, Message: "No token function is configured to authenticate against the satellite."}
+	}
 	accessToken, httpErr := icr.tokenFunc(icr.satelliteAr)
 	if httpErr != (model.HttpError{}) {
 		logger.Debugf("Was not able to get a token from the satellite at %s.", logging.PrettyPrintObject(icr.satelliteAr))
@@ -200,6 +328,9 @@ func (icr IShareTrustedParticipantRepository) getTrustedParty(id string) (truste
 }
 
 func (icr IShareTrustedParticipantRepository) getTrustedList() (trustedList *[]model.TrustedParticipant, httpErr model.HttpError) {
+	if icr.tokenFunc == nil {
+		return trustedList, model.HttpError{Status: http.StatusInternalServerError, Message: "No token function is configured to authenticate against the satellite."}
+	}
 	accessToken, httpErr := icr.tokenFunc(icr.satelliteAr)
 	if httpErr != (model.HttpError{}) {
 		logger.Debugf("Was not able to get a token from the satellite at %s.", logging.PrettyPrintObject(icr.satelliteAr))
@@ -239,6 +370,23 @@ func (icr IShareTrustedParticipantRepository) getTrustedList() (trustedList *[]m
 	return parsedToken.TrustedList, httpErr
 }
 
+// isRevoked asks the configured RevocationChecker whether clientCertificate
+// has been revoked by issuer. RevocationChecker.Check already applies the
+// configured SoftFail/HardFail mode internally: in SoftFail it swallows an
+// undeterminable status into (RevocationStatusGood, nil), so an error
+// reaching here only ever happens in HardFail mode, and must fail closed.
+func (icr IShareTrustedParticipantRepository) isRevoked(clientCertificate *x509.Certificate, issuer *x509.Certificate) bool {
+	if icr.revocationChecker == nil {
+		return false
+	}
+	status, err := icr.revocationChecker.Check(context.Background(), clientCertificate, issuer)
+	if err != nil {
+		logger.Warnf("Was not able to determine the revocation status for %s, failing closed. Err: %v", clientCertificate.Subject, err)
+		return true
+	}
+	return status == RevocationStatusRevoked
+}
+
 func buildCertificateFingerprint(certificate *x509.Certificate) (fingerprint string) {
 
 	fingerprintBytes := sha256.Sum256(certificate.Raw)
@@ -254,6 +402,29 @@ func buildCertificateFingerprint(certificate *x509.Certificate) (fingerprint str
 	return buf.String()
 }
 
+// organizationIdentifierOid is the eIDAS OID (2.5.4.97) carrying a
+// participant's EORI in its certificate subject, e.g.
+// "organizationIdentifier=EU.EORI.NLPACKETDEL".
+var organizationIdentifierOid = asn1.ObjectIdentifier{2, 5, 4, 97}
+
+// eoriMatchesCertificate checks clientId against the EORI carried by
+// certificate's subject, first in the dedicated serialNumber field (the
+// common iSHARE convention) and, failing that, in the organizationIdentifier
+// (OID 2.5.4.97) attribute per eIDAS.
+func eoriMatchesCertificate(certificate *x509.Certificate, clientId string) bool {
+	if certificate.Subject.SerialNumber != "" && strings.EqualFold(certificate.Subject.SerialNumber, clientId) {
+		return true
+	}
+	for _, name := range certificate.Subject.Names {
+		if name.Type.Equal(organizationIdentifierOid) {
+			if value, ok := name.Value.(string); ok && strings.EqualFold(value, clientId) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -0,0 +1,195 @@
+package ishare
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestContains(t *testing.T) {
+	if !contains([]string{"a", "b"}, "b") {
+		t.Error("expected b to be found")
+	}
+	if contains([]string{"a", "b"}, "c") {
+		t.Error("expected c to not be found")
+	}
+}
+
+func TestTrustMode(t *testing.T) {
+	t.Setenv(TrustModeEnvVar, "")
+	if mode := trustMode(); mode != TrustModeFingerprint {
+		t.Errorf("expected the default trust mode to be %q, got %q", TrustModeFingerprint, mode)
+	}
+	t.Setenv(TrustModeEnvVar, TrustModeChain)
+	if mode := trustMode(); mode != TrustModeChain {
+		t.Errorf("expected the configured trust mode to be %q, got %q", TrustModeChain, mode)
+	}
+}
+
+func TestBuildCertificateFingerprintIsStableAndDistinct(t *testing.T) {
+	first := generateTestCertificate(t, "EU.EORI.NLFIRST", nil)
+	second := generateTestCertificate(t, "EU.EORI.NLSECOND", nil)
+
+	if buildCertificateFingerprint(first) != buildCertificateFingerprint(first) {
+		t.Error("expected the fingerprint of the same certificate to be stable across calls")
+	}
+	if buildCertificateFingerprint(first) == buildCertificateFingerprint(second) {
+		t.Error("expected different certificates to have different fingerprints")
+	}
+}
+
+func TestEoriMatchesCertificateSerialNumber(t *testing.T) {
+	cert := generateTestCertificate(t, "EU.EORI.NLPACKETDEL", nil)
+	if !eoriMatchesCertificate(cert, "EU.EORI.NLPACKETDEL") {
+		t.Error("expected the EORI in the serialNumber field to match")
+	}
+	if eoriMatchesCertificate(cert, "EU.EORI.NLOTHER") {
+		t.Error("expected a different EORI to not match")
+	}
+}
+
+func TestEoriMatchesCertificateOrganizationIdentifier(t *testing.T) {
+	cert := generateTestCertificate(t, "", []pkix.AttributeTypeAndValue{
+		{Type: organizationIdentifierOid, Value: "EU.EORI.NLPACKETDEL"},
+	})
+	if !eoriMatchesCertificate(cert, "EU.EORI.NLPACKETDEL") {
+		t.Error("expected the EORI in the organizationIdentifier attribute to match")
+	}
+}
+
+// TestIsTrustedChainVerifiesAgainstCachedCa exercises the chunk0-4 chain
+// verification path end to end: a client certificate signed by an
+// intermediate CA must verify against a root pre-populated in
+// trustedCaCertificateCache, the way resolveTrustedCaCertificates
+// populates it from a configured CA bundle.
+func TestIsTrustedChainVerifiesAgainstCachedCa(t *testing.T) {
+	root, rootKey := generateTestCa(t, "Test Root CA", nil, nil)
+	intermediate, intermediateKey := generateTestCa(t, "Test Intermediate CA", root, rootKey)
+	client := generateTestClientCert(t, "EU.EORI.NLPACKETDEL", intermediate, intermediateKey)
+
+	rootFingerprint := buildCertificateFingerprint(root)
+	trustedCaCertificateCache.put(rootFingerprint, root)
+
+	repo := IShareTrustedParticipantRepository{trustedFingerprints: []string{rootFingerprint}}
+	if !repo.isTrustedChain(intermediate, client, "EU.EORI.NLPACKETDEL") {
+		t.Error("expected a client certificate chaining up to a cached root to be trusted")
+	}
+}
+
+func TestIsTrustedChainFailsWithEmptyCache(t *testing.T) {
+	root, rootKey := generateTestCa(t, "Test Root CA", nil, nil)
+	intermediate, intermediateKey := generateTestCa(t, "Test Intermediate CA", root, rootKey)
+	client := generateTestClientCert(t, "EU.EORI.NLPACKETDEL", intermediate, intermediateKey)
+
+	repo := IShareTrustedParticipantRepository{trustedFingerprints: []string{buildCertificateFingerprint(root)}}
+	if repo.isTrustedChain(intermediate, client, "EU.EORI.NLPACKETDEL") {
+		t.Error("expected isTrustedChain to fail when no CA certificate has been resolved into the cache yet")
+	}
+}
+
+func TestIsTrustedChainRejectsEoriMismatch(t *testing.T) {
+	root, rootKey := generateTestCa(t, "Test Root CA", nil, nil)
+	intermediate, intermediateKey := generateTestCa(t, "Test Intermediate CA", root, rootKey)
+	client := generateTestClientCert(t, "EU.EORI.NLPACKETDEL", intermediate, intermediateKey)
+
+	rootFingerprint := buildCertificateFingerprint(root)
+	trustedCaCertificateCache.put(rootFingerprint, root)
+
+	repo := IShareTrustedParticipantRepository{trustedFingerprints: []string{rootFingerprint}}
+	if repo.isTrustedChain(intermediate, client, "EU.EORI.NLDIFFERENT") {
+		t.Error("expected a verified chain with a mismatching EORI to not be trusted")
+	}
+}
+
+func generateTestCertificate(t *testing.T, serialNumber string, extraNames []pkix.AttributeTypeAndValue) *x509.Certificate {
+	t.Helper()
+	_, cert := generateSelfSignedWithSubject(t, pkix.Name{CommonName: "test", SerialNumber: serialNumber, ExtraNames: extraNames}, true)
+	return cert
+}
+
+func generateTestCa(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("was not able to generate a test key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	signingParent := template
+	signingKey := key
+	if parent != nil {
+		signingParent = parent
+		signingKey = parentKey
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, signingParent, &key.PublicKey, signingKey)
+	if err != nil {
+		t.Fatalf("was not able to create a test CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		t.Fatalf("was not able to parse the generated test CA certificate: %v", err)
+	}
+	return cert, key
+}
+
+func generateTestClientCert(t *testing.T, eori string, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("was not able to generate a test key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: eori, SerialNumber: eori},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatalf("was not able to create a test client certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		t.Fatalf("was not able to parse the generated test client certificate: %v", err)
+	}
+	return cert
+}
+
+func generateSelfSignedWithSubject(t *testing.T, subject pkix.Name, isCa bool) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("was not able to generate a test key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               subject,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCa,
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("was not able to create a self-signed test certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		t.Fatalf("was not able to parse the generated self-signed test certificate: %v", err)
+	}
+	return key, cert
+}
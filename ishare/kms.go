@@ -0,0 +1,155 @@
+package ishare
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ISHARE_SIGNER_KMS selects the key-manager backend (e.g. "softkms",
+// "pkcs11", "awskms", "gcpkms") used when ISHARE_SIGNER_KEY_URI does not
+// already carry a scheme.
+const SignerKmsEnvVar = "ISHARE_SIGNER_KMS"
+
+// ISHARE_SIGNER_KEY_URI points the signer to the private key to use, e.g.
+// "softkms:/etc/ishare/key.pem" or
+// "pkcs11:slot-id=0;object=ishare-signer?pin-value=1234".
+const SignerKeyUriEnvVar = "ISHARE_SIGNER_KEY_URI"
+
+const defaultKmsType = "softkms"
+
+// KeyURI identifies a private key managed by a KeyManager. The scheme
+// (the part before the first ":") selects the backend, the remainder is
+// backend-specific, following the same convention as smallstep/kms.
+type KeyURI string
+
+// Scheme returns the KMS type encoded in the URI, e.g. "pkcs11" for
+// "pkcs11:slot-id=0;object=ishare-signer". A bare path without a scheme
+// is treated as "softkms", to keep the pre-existing file-based behaviour
+// working without any configuration change.
+func (k KeyURI) Scheme() string {
+	scheme, _, found := strings.Cut(string(k), ":")
+	if !found {
+		return defaultKmsType
+	}
+	return scheme
+}
+
+// KeyManager resolves a crypto.Signer for a given KeyURI. Implementations
+// are registered with RegisterKeyManager and selected by the URI scheme.
+type KeyManager interface {
+	Signer(ctx context.Context, keyURI KeyURI) (crypto.Signer, error)
+}
+
+// NewKeyManagerFunc builds a KeyManager for a concrete backend.
+type NewKeyManagerFunc func() (KeyManager, error)
+
+var (
+	keyManagerRegistryLock sync.Mutex
+	keyManagerRegistry     = map[string]NewKeyManagerFunc{}
+)
+
+// RegisterKeyManager registers a KeyManager constructor under the given
+// scheme. External packages can call this from an init() function to
+// plug in their own backend without changing this package.
+func RegisterKeyManager(scheme string, newFunc NewKeyManagerFunc) {
+	keyManagerRegistryLock.Lock()
+	defer keyManagerRegistryLock.Unlock()
+	keyManagerRegistry[scheme] = newFunc
+}
+
+func init() {
+	RegisterKeyManager("softkms", func() (KeyManager, error) { return new(softKms), nil })
+	RegisterKeyManager("pkcs11", newPkcs11Kms)
+	RegisterKeyManager("awskms", newAwsKms)
+	RegisterKeyManager("gcpkms", newGcpKms)
+}
+
+var (
+	keyManagerInstanceLock sync.Mutex
+	keyManagerInstances    = map[string]KeyManager{}
+
+	resolvedSignerLock sync.Mutex
+	resolvedSigners    = map[KeyURI]crypto.Signer{}
+)
+
+// Signer resolves a crypto.Signer for keyURI through the KeyManager
+// registered for its scheme. It is the single entry point the token
+// signer and any outbound iShare assertion signing should use to get
+// hold of the private key, instead of reading a PEM file directly.
+//
+// Both the KeyManager (one per scheme) and the resolved crypto.Signer
+// (one per KeyURI) are cached, since a HSM/KMS-backed KeyManager may
+// open a session or fetch the public key over the network to produce
+// one - too expensive to redo on every call if this is used for
+// per-request token signing.
+func Signer(ctx context.Context, keyURI KeyURI) (crypto.Signer, error) {
+	resolvedSignerLock.Lock()
+	if signer, ok := resolvedSigners[keyURI]; ok {
+		resolvedSignerLock.Unlock()
+		return signer, nil
+	}
+	resolvedSignerLock.Unlock()
+
+	keyManager, err := keyManagerFor(keyURI.Scheme())
+	if err != nil {
+		return nil, err
+	}
+	signer, err := keyManager.Signer(ctx, keyURI)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to get a signer for %s: %w", keyURI.Scheme(), err)
+	}
+
+	resolvedSignerLock.Lock()
+	resolvedSigners[keyURI] = signer
+	resolvedSignerLock.Unlock()
+
+	return signer, nil
+}
+
+// keyManagerFor returns the (lazily created, cached) KeyManager for
+// scheme.
+func keyManagerFor(scheme string) (KeyManager, error) {
+	keyManagerInstanceLock.Lock()
+	defer keyManagerInstanceLock.Unlock()
+
+	if keyManager, ok := keyManagerInstances[scheme]; ok {
+		return keyManager, nil
+	}
+
+	keyManagerRegistryLock.Lock()
+	newFunc, ok := keyManagerRegistry[scheme]
+	keyManagerRegistryLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no key manager registered for scheme %s", scheme)
+	}
+
+	keyManager, err := newFunc()
+	if err != nil {
+		return nil, fmt.Errorf("was not able to initialize the %s key manager: %w", scheme, err)
+	}
+	keyManagerInstances[scheme] = keyManager
+	return keyManager, nil
+}
+
+// ConfiguredKeyURI builds the KeyURI to use for token signing from the
+// ISHARE_SIGNER_KMS/ISHARE_SIGNER_KEY_URI env vars. It returns an empty
+// KeyURI when neither is configured, so callers can fall back to their
+// previous, implicit PEM-on-disk behaviour.
+func ConfiguredKeyURI() KeyURI {
+	keyUriEnv := os.Getenv(SignerKeyUriEnvVar)
+	if keyUriEnv == "" {
+		return ""
+	}
+	if strings.Contains(keyUriEnv, ":") {
+		return KeyURI(keyUriEnv)
+	}
+	kmsType := os.Getenv(SignerKmsEnvVar)
+	if kmsType == "" {
+		kmsType = defaultKmsType
+	}
+	return KeyURI(fmt.Sprintf("%s:%s", kmsType, keyUriEnv))
+}
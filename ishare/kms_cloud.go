@@ -0,0 +1,174 @@
+package ishare
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"strings"
+
+	gcpkms "cloud.google.com/go/kms/apiv1"
+	kmspb "cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	awskms "github.com/aws/aws-sdk-go-v2/service/kms"
+	awskmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// awsKms resolves signers backed by a key held in AWS KMS, addressed via
+// an "awskms:key/<key-id>" style URI. The region is taken from the
+// process's regular AWS config/credential chain.
+type awsKms struct {
+	client *awskms.Client
+}
+
+func newAwsKms() (KeyManager, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("was not able to load the AWS config: %w", err)
+	}
+	return &awsKms{client: awskms.NewFromConfig(cfg)}, nil
+}
+
+func (a *awsKms) Signer(ctx context.Context, keyURI KeyURI) (crypto.Signer, error) {
+	keyID := strings.TrimPrefix(string(keyURI), "awskms:")
+	if keyID == "" {
+		return nil, fmt.Errorf("awskms key uri %q is missing the key id", keyURI)
+	}
+
+	pubKeyOutput, err := a.client.GetPublicKey(ctx, &awskms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("was not able to get the public key for %s: %w", keyID, err)
+	}
+	publicKey, err := parsePublicKeyDer(pubKeyOutput.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to parse the public key for %s: %w", keyID, err)
+	}
+
+	return &awsKmsSigner{client: a.client, keyID: keyID, publicKey: publicKey}, nil
+}
+
+// awsKmsSigner implements crypto.Signer by delegating Sign to the AWS KMS
+// Sign API, so the private key material never leaves the HSM.
+type awsKmsSigner struct {
+	client    *awskms.Client
+	keyID     string
+	publicKey crypto.PublicKey
+}
+
+func (s *awsKmsSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *awsKmsSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	algorithm, err := awsSigningAlgorithm(s.publicKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	output, err := s.client.Sign(context.Background(), &awskms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      awskmstypes.MessageTypeDigest,
+		SigningAlgorithm: algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("was not able to sign with AWS KMS key %s: %w", s.keyID, err)
+	}
+	return output.Signature, nil
+}
+
+// awsSigningAlgorithm picks the SigningAlgorithmSpec matching both the
+// key's actual type and the requested hash, since AWS KMS rejects a
+// Sign call whose algorithm does not match the key's KeySpec (e.g.
+// requesting an RSASSA algorithm against an EC key).
+func awsSigningAlgorithm(publicKey crypto.PublicKey, opts crypto.SignerOpts) (awskmstypes.SigningAlgorithmSpec, error) {
+	switch publicKey.(type) {
+	case *ecdsa.PublicKey:
+		switch opts.HashFunc() {
+		case crypto.SHA256:
+			return awskmstypes.SigningAlgorithmSpecEcdsaSha256, nil
+		case crypto.SHA384:
+			return awskmstypes.SigningAlgorithmSpecEcdsaSha384, nil
+		default:
+			return "", fmt.Errorf("unsupported hash function %s for AWS KMS ECDSA signing", opts.HashFunc())
+		}
+	case *rsa.PublicKey:
+		switch opts.HashFunc() {
+		case crypto.SHA256:
+			return awskmstypes.SigningAlgorithmSpecRsassaPkcs1V15Sha256, nil
+		case crypto.SHA384:
+			return awskmstypes.SigningAlgorithmSpecRsassaPkcs1V15Sha384, nil
+		default:
+			return "", fmt.Errorf("unsupported hash function %s for AWS KMS RSA signing", opts.HashFunc())
+		}
+	default:
+		return "", fmt.Errorf("unsupported public key type %T for AWS KMS signing", publicKey)
+	}
+}
+
+// gcpKms resolves signers backed by a key held in Google Cloud KMS,
+// addressed via a "gcpkms:projects/.../cryptoKeyVersions/1" style URI.
+type gcpKms struct {
+	client *gcpkms.KeyManagementClient
+}
+
+func newGcpKms() (KeyManager, error) {
+	client, err := gcpkms.NewKeyManagementClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("was not able to create the Cloud KMS client: %w", err)
+	}
+	return &gcpKms{client: client}, nil
+}
+
+func (g *gcpKms) Signer(ctx context.Context, keyURI KeyURI) (crypto.Signer, error) {
+	resourceName := strings.TrimPrefix(string(keyURI), "gcpkms:")
+	if resourceName == "" {
+		return nil, fmt.Errorf("gcpkms key uri %q is missing the resource name", keyURI)
+	}
+
+	pubKeyResponse, err := g.client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: resourceName})
+	if err != nil {
+		return nil, fmt.Errorf("was not able to get the public key for %s: %w", resourceName, err)
+	}
+	publicKey, err := parsePublicKeyPem(pubKeyResponse.Pem)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to parse the public key for %s: %w", resourceName, err)
+	}
+
+	return &gcpKmsSigner{client: g.client, resourceName: resourceName, publicKey: publicKey}, nil
+}
+
+// gcpKmsSigner implements crypto.Signer by delegating Sign to the Cloud
+// KMS AsymmetricSign API, so the private key material never leaves the
+// HSM backing the key ring.
+type gcpKmsSigner struct {
+	client       *gcpkms.KeyManagementClient
+	resourceName string
+	publicKey    crypto.PublicKey
+}
+
+func (s *gcpKmsSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *gcpKmsSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	response, err := s.client.AsymmetricSign(context.Background(), &kmspb.AsymmetricSignRequest{
+		Name:   s.resourceName,
+		Digest: gcpDigest(digest, opts),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("was not able to sign with Cloud KMS key %s: %w", s.resourceName, err)
+	}
+	return response.Signature, nil
+}
+
+func gcpDigest(digest []byte, opts crypto.SignerOpts) *kmspb.Digest {
+	switch opts.HashFunc() {
+	case crypto.SHA384:
+		return &kmspb.Digest{Digest: &kmspb.Digest_Sha384{Sha384: digest}}
+	default:
+		return &kmspb.Digest{Digest: &kmspb.Digest_Sha256{Sha256: digest}}
+	}
+}
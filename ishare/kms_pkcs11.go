@@ -0,0 +1,54 @@
+//go:build cgo
+
+package ishare
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+// pkcs11Kms resolves signers backed by a PKCS#11 token, addressed via a
+// "pkcs11:slot-id=0;object=ishare-signer?pin-value=1234" style URI, as
+// used by the PKCS#11 URI scheme (RFC 7512).
+type pkcs11Kms struct {
+	ctx *crypto11.Context
+}
+
+func newPkcs11Kms() (KeyManager, error) {
+	if os.Getenv(Pkcs11ModulePathEnvVar) == "" {
+		return nil, fmt.Errorf("%s is not set, cannot load a PKCS#11 module", Pkcs11ModulePathEnvVar)
+	}
+	return &pkcs11Kms{}, nil
+}
+
+func (p *pkcs11Kms) Signer(ctx context.Context, keyURI KeyURI) (crypto.Signer, error) {
+	attrs, err := parsePkcs11Uri(keyURI)
+	if err != nil {
+		return nil, err
+	}
+
+	modulePath := os.Getenv(Pkcs11ModulePathEnvVar)
+	cfg := &crypto11.Config{
+		Path:       modulePath,
+		SlotNumber: attrs.slotID,
+		Pin:        attrs.pin,
+	}
+	pkcsCtx, err := crypto11.Configure(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to initialize the HSM at %s: %w", modulePath, err)
+	}
+	p.ctx = pkcsCtx
+
+	signer, err := pkcsCtx.FindKeyPair(nil, []byte(attrs.object))
+	if err != nil {
+		return nil, fmt.Errorf("was not able to find the key pair %q on the HSM: %w", attrs.object, err)
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("no key pair named %q was found on the HSM", attrs.object)
+	}
+	return signer, nil
+}
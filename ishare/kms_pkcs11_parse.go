@@ -0,0 +1,54 @@
+package ishare
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Pkcs11ModulePathEnvVar points to the vendor-provided PKCS#11 module
+// (.so) to load, e.g. the SoftHSM2 or a network HSM's library.
+const Pkcs11ModulePathEnvVar = "ISHARE_SIGNER_PKCS11_MODULE"
+
+type pkcs11Attrs struct {
+	slotID int
+	object string
+	pin    string
+}
+
+// parsePkcs11Uri extracts the attributes this repository cares about from
+// a PKCS#11 URI (RFC 7512), e.g.
+// "pkcs11:slot-id=0;object=ishare-signer?pin-value=1234". It has no cgo
+// dependency, so it is built (and tested) regardless of CGO_ENABLED.
+func parsePkcs11Uri(keyURI KeyURI) (attrs pkcs11Attrs, err error) {
+	raw := strings.TrimPrefix(string(keyURI), "pkcs11:")
+	path, query, _ := strings.Cut(raw, "?")
+
+	for _, part := range strings.Split(path, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "slot-id":
+			if _, scanErr := fmt.Sscanf(value, "%d", &attrs.slotID); scanErr != nil {
+				return attrs, fmt.Errorf("invalid slot-id %q in %s", value, keyURI)
+			}
+		case "object":
+			attrs.object = value
+		}
+	}
+
+	if query != "" {
+		values, parseErr := url.ParseQuery(query)
+		if parseErr != nil {
+			return attrs, fmt.Errorf("invalid query in %s: %w", keyURI, parseErr)
+		}
+		attrs.pin = values.Get("pin-value")
+	}
+
+	if attrs.object == "" {
+		return attrs, fmt.Errorf("pkcs11 key uri %s is missing the object attribute", keyURI)
+	}
+	return attrs, nil
+}
@@ -0,0 +1,31 @@
+package ishare
+
+import "testing"
+
+func TestParsePkcs11Uri(t *testing.T) {
+	attrs, err := parsePkcs11Uri("pkcs11:slot-id=0;object=ishare-signer?pin-value=1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attrs.slotID != 0 {
+		t.Errorf("expected slot-id 0, got %d", attrs.slotID)
+	}
+	if attrs.object != "ishare-signer" {
+		t.Errorf("expected object ishare-signer, got %q", attrs.object)
+	}
+	if attrs.pin != "1234" {
+		t.Errorf("expected pin 1234, got %q", attrs.pin)
+	}
+}
+
+func TestParsePkcs11UriMissingObject(t *testing.T) {
+	if _, err := parsePkcs11Uri("pkcs11:slot-id=0"); err == nil {
+		t.Error("expected an error for a uri without an object attribute")
+	}
+}
+
+func TestParsePkcs11UriInvalidSlot(t *testing.T) {
+	if _, err := parsePkcs11Uri("pkcs11:slot-id=not-a-number;object=ishare-signer"); err == nil {
+		t.Error("expected an error for a non-numeric slot-id")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !cgo
+
+package ishare
+
+import "fmt"
+
+// newPkcs11Kms is the CGO_ENABLED=0 fallback for the pkcs11 scheme: the
+// real implementation in kms_pkcs11.go links against a PKCS#11 module
+// through cgo, so a pure-Go build registers this stub instead, which
+// fails clearly at first use rather than at compile time.
+func newPkcs11Kms() (KeyManager, error) {
+	return nil, fmt.Errorf("pkcs11 key manager support requires a cgo-enabled build")
+}
@@ -0,0 +1,61 @@
+package ishare
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// softKms is the built-in, file-based KeyManager. It is the direct
+// continuation of the previous behaviour, where the signer's private key
+// was read from a PEM file on disk.
+type softKms struct{}
+
+func (softKms) Signer(ctx context.Context, keyURI KeyURI) (crypto.Signer, error) {
+	_, path, found := strings.Cut(string(keyURI), ":")
+	if !found {
+		// a bare path, see KeyURI.Scheme
+		path = string(keyURI)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("softkms key uri %q does not contain a file path", keyURI)
+	}
+
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to read the key file %s: %w", path, err)
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded key", path)
+	}
+
+	key, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to parse the private key %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("the key at %s does not implement crypto.Signer", path)
+	}
+	return signer, nil
+}
+
+// parsePrivateKey tries the PKCS#1, EC and PKCS#8 encodings in turn, since
+// a PEM block's header alone does not reliably tell them apart.
+func parsePrivateKey(block *pem.Block) (key any, err error) {
+	if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err = x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key encoding")
+}
@@ -0,0 +1,174 @@
+package ishare
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	awskmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/fiware/dsba-pdp/model"
+)
+
+func TestTokenFuncOrDefaultKeepsCallerSuppliedFunc(t *testing.T) {
+	called := false
+	tokenFunc := func(ar *model.AuthorizationRegistry) (string, model.HttpError) {
+		called = true
+		return "token", model.HttpError{}
+	}
+	resolved := tokenFuncOrDefault(tokenFunc)
+	if resolved == nil {
+		t.Fatal("expected the caller-supplied TokenFunc to be kept")
+	}
+	if _, _ = resolved(&model.AuthorizationRegistry{}); !called {
+		t.Error("expected the caller-supplied TokenFunc to be the one invoked")
+	}
+}
+
+func TestTokenFuncOrDefaultWithoutConfiguredKeyUri(t *testing.T) {
+	t.Setenv(SignerKeyUriEnvVar, "")
+	if resolved := tokenFuncOrDefault(nil); resolved != nil {
+		t.Error("expected no default TokenFunc when no signer key uri is configured")
+	}
+}
+
+func TestTokenFuncOrDefaultBuildsKmsTokenFuncWhenConfigured(t *testing.T) {
+	t.Setenv(SignerKeyUriEnvVar, "pkcs11:slot-id=0;object=ishare-signer")
+	t.Setenv(ClientEoriEnvVar, "EU.EORI.NLPACKETDEL")
+	if resolved := tokenFuncOrDefault(nil); resolved == nil {
+		t.Error("expected a KMS-backed TokenFunc to be built when a signer key uri and client EORI are configured")
+	}
+}
+
+func TestTokenFuncOrDefaultRequiresClientEori(t *testing.T) {
+	t.Setenv(SignerKeyUriEnvVar, "pkcs11:slot-id=0;object=ishare-signer")
+	t.Setenv(ClientEoriEnvVar, "")
+	if resolved := tokenFuncOrDefault(nil); resolved != nil {
+		t.Error("expected no default TokenFunc without a configured client EORI, even with a signer key uri")
+	}
+}
+
+func TestGetTrustedPartyAndListFailClearlyWithoutTokenFunc(t *testing.T) {
+	repo := IShareTrustedParticipantRepository{satelliteAr: &model.AuthorizationRegistry{}}
+
+	if _, httpErr := repo.getTrustedParty("EU.EORI.NLPACKETDEL"); httpErr == (model.HttpError{}) {
+		t.Error("expected a clear HttpError, not a nil-pointer panic, when no TokenFunc is configured")
+	}
+	if _, httpErr := repo.getTrustedList(); httpErr == (model.HttpError{}) {
+		t.Error("expected a clear HttpError, not a nil-pointer panic, when no TokenFunc is configured")
+	}
+}
+
+func TestAwsSigningAlgorithmMatchesKeyType(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("was not able to generate a test EC key: %v", err)
+	}
+	if algorithm, err := awsSigningAlgorithm(&ecKey.PublicKey, crypto.SHA256); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if algorithm != awskmstypes.SigningAlgorithmSpecEcdsaSha256 {
+		t.Errorf("expected an ECDSA signing algorithm for an EC key, got %s", algorithm)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("was not able to generate a test RSA key: %v", err)
+	}
+	if algorithm, err := awsSigningAlgorithm(&rsaKey.PublicKey, crypto.SHA256); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if algorithm != awskmstypes.SigningAlgorithmSpecRsassaPkcs1V15Sha256 {
+		t.Errorf("expected an RSASSA signing algorithm for an RSA key, got %s", algorithm)
+	}
+}
+
+func TestKeyUriScheme(t *testing.T) {
+	if scheme := KeyURI("pkcs11:slot-id=0;object=ishare-signer").Scheme(); scheme != "pkcs11" {
+		t.Errorf("expected scheme pkcs11, got %q", scheme)
+	}
+	if scheme := KeyURI("/etc/ishare/key.pem").Scheme(); scheme != defaultKmsType {
+		t.Errorf("expected a bare path to default to %q, got %q", defaultKmsType, scheme)
+	}
+}
+
+func TestConfiguredKeyUri(t *testing.T) {
+	t.Setenv(SignerKeyUriEnvVar, "")
+	if keyURI := ConfiguredKeyURI(); keyURI != "" {
+		t.Errorf("expected an empty KeyURI when unconfigured, got %q", keyURI)
+	}
+
+	t.Setenv(SignerKeyUriEnvVar, "pkcs11:slot-id=0;object=ishare-signer")
+	if keyURI := ConfiguredKeyURI(); keyURI != "pkcs11:slot-id=0;object=ishare-signer" {
+		t.Errorf("expected a URI with an explicit scheme to pass through unchanged, got %q", keyURI)
+	}
+
+	t.Setenv(SignerKeyUriEnvVar, "/etc/ishare/key.pem")
+	t.Setenv(SignerKmsEnvVar, "softkms")
+	if keyURI := ConfiguredKeyURI(); keyURI != "softkms:/etc/ishare/key.pem" {
+		t.Errorf("expected the configured kms type to be prefixed onto a bare path, got %q", keyURI)
+	}
+}
+
+func TestSoftKmsSignerRoundTrip(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("was not able to generate a test key: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("was not able to marshal the test key: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(keyPath, pemBytes, 0600); err != nil {
+		t.Fatalf("was not able to write the test key: %v", err)
+	}
+
+	signer, err := (softKms{}).Signer(context.Background(), KeyURI("softkms:"+keyPath))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !key.PublicKey.Equal(signer.Public()) {
+		t.Error("expected the resolved signer's public key to match the generated key")
+	}
+}
+
+func TestSignerIsCachedPerKeyUri(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("was not able to generate a test key: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("was not able to marshal the test key: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(keyPath, pemBytes, 0600); err != nil {
+		t.Fatalf("was not able to write the test key: %v", err)
+	}
+	keyURI := KeyURI("softkms:" + keyPath)
+
+	first, err := Signer(context.Background(), keyURI)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Remove the backing file: a cache hit must not need to re-read it.
+	if err := os.Remove(keyPath); err != nil {
+		t.Fatalf("was not able to remove the test key: %v", err)
+	}
+	second, err := Signer(context.Background(), keyURI)
+	if err != nil {
+		t.Fatalf("expected the cached signer to be returned without re-reading the key file, got error: %v", err)
+	}
+	if first != second {
+		t.Error("expected Signer to return the same cached instance for the same KeyURI")
+	}
+}
@@ -0,0 +1,188 @@
+package ishare
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fiware/dsba-pdp/model"
+)
+
+// ISHARE_SIGNER_CERTIFICATE_CHAIN points to a PEM bundle holding the
+// signer's own certificate (and any intermediates), used to populate the
+// "x5c" header of the client_assertion JWT sent to an authorization
+// registry's token endpoint.
+const SignerCertificateChainEnvVar = "ISHARE_SIGNER_CERTIFICATE_CHAIN"
+
+// NewKmsTokenFunc builds a TokenFunc that requests an access token at an
+// authorization registry using the iSHARE client_assertion grant, signed
+// with the private key resolved through the KeyManager abstraction (see
+// kms.go) rather than a PEM key read inline - the same abstraction used
+// for any other outbound iShare assertion signing.
+func NewKmsTokenFunc(keyURI KeyURI, clientEori string) TokenFunc {
+	return func(ar *model.AuthorizationRegistry) (token string, httpErr model.HttpError) {
+		assertion, err := buildClientAssertion(keyURI, clientEori, ar.Id)
+		if err != nil {
+			return "", model.HttpError{Status: http.StatusInternalServerError, Message: "Was not able to build the client assertion.", RootError: err}
+		}
+
+		form := url.Values{}
+		form.Set("grant_type", "client_credentials")
+		form.Set("scope", "iSHARE")
+		form.Set("client_id", clientEori)
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", assertion)
+
+		tokenRequest, err := http.NewRequest("POST", ar.Host+ar.TokenPath, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", model.HttpError{Status: http.StatusInternalServerError, Message: "Was not able to create the token request.", RootError: err}
+		}
+		tokenRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		tokenResponse, err := globalHttpClient.Do(tokenRequest)
+		if err != nil || tokenResponse == nil {
+			return "", model.HttpError{Status: http.StatusBadGateway, Message: "Was not able to retrieve a token from the authorization registry.", RootError: err}
+		}
+		defer tokenResponse.Body.Close()
+		if tokenResponse.StatusCode != 200 {
+			return "", model.HttpError{Status: http.StatusBadGateway, Message: fmt.Sprintf("Received status %s from the token endpoint.", tokenResponse.Status)}
+		}
+
+		var tokenResponseObject struct {
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(tokenResponse.Body).Decode(&tokenResponseObject); err != nil {
+			return "", model.HttpError{Status: http.StatusBadGateway, Message: "Was not able to decode the token response.", RootError: err}
+		}
+		return tokenResponseObject.AccessToken, httpErr
+	}
+}
+
+// buildClientAssertion signs a compact JWT for the iSHARE client_assertion
+// grant, resolving the private key through Signer(ctx, keyURI) and
+// carrying the signer's certificate chain in the "x5c" header.
+func buildClientAssertion(keyURI KeyURI, clientEori string, audience string) (string, error) {
+	signer, err := Signer(context.Background(), keyURI)
+	if err != nil {
+		return "", fmt.Errorf("was not able to resolve the signer: %w", err)
+	}
+	chain, err := signerCertificateChain()
+	if err != nil {
+		return "", err
+	}
+	algorithm, hash := signingAlgorithmFor(signer.Public())
+
+	now := time.Now()
+	header, err := jwtSegment(map[string]any{
+		"alg": algorithm,
+		"typ": "JWT",
+		"x5c": chain,
+	})
+	if err != nil {
+		return "", err
+	}
+	payload, err := jwtSegment(map[string]any{
+		"iss": clientEori,
+		"sub": clientEori,
+		"aud": audience,
+		"jti": fmt.Sprintf("%x", now.UnixNano()),
+		"iat": now.Unix(),
+		"exp": now.Add(30 * time.Second).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := header + "." + payload
+	digest := hash.New()
+	digest.Write([]byte(signingInput))
+	signature, err := signer.Sign(rand.Reader, digest.Sum(nil), hash)
+	if err != nil {
+		return "", fmt.Errorf("was not able to sign the client assertion: %w", err)
+	}
+	if ecKey, isEc := signer.Public().(*ecdsa.PublicKey); isEc {
+		// crypto.Signer returns ECDSA signatures ASN.1 encoded, a JWS carries
+		// them as the fixed-size R||S concatenation instead.
+		var ecSignature struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &ecSignature); err != nil {
+			return "", fmt.Errorf("was not able to decode the ECDSA signature: %w", err)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		ecSignature.R.FillBytes(signature[:size])
+		ecSignature.S.FillBytes(signature[size:])
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// signingAlgorithmFor picks the JWA algorithm name matching the signer's
+// key type, so the same assertion builder works for both the RSA and EC
+// keys any of the KeyManager backends may return.
+func signingAlgorithmFor(publicKey crypto.PublicKey) (algorithm string, hash crypto.Hash) {
+	switch publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return "ES256", crypto.SHA256
+	case *rsa.PublicKey:
+		return "RS256", crypto.SHA256
+	default:
+		return "RS256", crypto.SHA256
+	}
+}
+
+func jwtSegment(v map[string]any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("was not able to encode a JWT segment: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// signerCertificateChain loads the PEM bundle configured through
+// ISHARE_SIGNER_CERTIFICATE_CHAIN and base64-encodes each certificate for
+// the JWT's "x5c" header, per RFC 7515.
+func signerCertificateChain() ([]string, error) {
+	chainPath := os.Getenv(SignerCertificateChainEnvVar)
+	if chainPath == "" {
+		return nil, fmt.Errorf("%s is not configured, cannot build the x5c header", SignerCertificateChainEnvVar)
+	}
+	pemBytes, err := os.ReadFile(chainPath)
+	if err != nil {
+		return nil, fmt.Errorf("was not able to read the certificate chain %s: %w", chainPath, err)
+	}
+
+	var chain []string
+	rest := pemBytes
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return nil, fmt.Errorf("was not able to parse a certificate in %s: %w", chainPath, err)
+		}
+		chain = append(chain, base64.StdEncoding.EncodeToString(block.Bytes))
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%s does not contain any certificate", chainPath)
+	}
+	return chain, nil
+}
@@ -0,0 +1,24 @@
+package ishare
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// parsePublicKeyDer parses a DER encoded SubjectPublicKeyInfo, as returned
+// by the AWS KMS GetPublicKey API.
+func parsePublicKeyDer(der []byte) (crypto.PublicKey, error) {
+	return x509.ParsePKIXPublicKey(der)
+}
+
+// parsePublicKeyPem parses a PEM encoded SubjectPublicKeyInfo, as
+// returned by the Cloud KMS GetPublicKey API.
+func parsePublicKeyPem(pemStr string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemStr))
+	if block == nil {
+		return nil, fmt.Errorf("not a PEM encoded public key")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,240 @@
+package ishare
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// ISHARE_REVOCATION_MODE selects what happens when a revocation status
+// could not be determined (issuer/OCSP responder unreachable, CRL stale,
+// ...): "softfail" (default, accept the certificate) or "hardfail"
+// (reject it).
+const RevocationModeEnvVar = "ISHARE_REVOCATION_MODE"
+
+const (
+	RevocationModeSoftFail = "softfail"
+	RevocationModeHardFail = "hardfail"
+)
+
+// RevocationStatus is the outcome of a revocation check for a single
+// certificate.
+type RevocationStatus int
+
+const (
+	RevocationStatusGood RevocationStatus = iota
+	RevocationStatusRevoked
+	RevocationStatusUnknown
+)
+
+// RevocationChecker validates that a certificate has not been revoked by
+// its issuer, using CRL Distribution Points and OCSP (RFC 6960) URIs
+// extracted from the certificate.
+type RevocationChecker interface {
+	Check(ctx context.Context, certificate *x509.Certificate, issuer *x509.Certificate) (RevocationStatus, error)
+}
+
+// revocationMetrics tracks counters operators can use to observe
+// revocation-checking behavior in production.
+type revocationMetrics struct {
+	lock          sync.Mutex
+	CacheHits     int64
+	CacheMisses   int64
+	OcspRequests  int64
+	OcspLatencyMs int64
+	FailOpenCount int64
+}
+
+func (m *revocationMetrics) recordCacheHit() {
+	m.lock.Lock()
+	m.CacheHits++
+	m.lock.Unlock()
+}
+
+func (m *revocationMetrics) recordCacheMiss() {
+	m.lock.Lock()
+	m.CacheMisses++
+	m.lock.Unlock()
+}
+
+func (m *revocationMetrics) recordOcspRequest(latency time.Duration) {
+	m.lock.Lock()
+	m.OcspRequests++
+	m.OcspLatencyMs += latency.Milliseconds()
+	m.lock.Unlock()
+}
+
+func (m *revocationMetrics) recordFailOpen() {
+	m.lock.Lock()
+	m.FailOpenCount++
+	m.lock.Unlock()
+}
+
+// Snapshot returns a point-in-time copy of the counters, safe to expose
+// e.g. via the /debug/trust endpoint.
+func (m *revocationMetrics) Snapshot() map[string]int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return map[string]int64{
+		"cache_hits":      m.CacheHits,
+		"cache_misses":    m.CacheMisses,
+		"ocsp_requests":   m.OcspRequests,
+		"ocsp_latency_ms": m.OcspLatencyMs,
+		"fail_open_count": m.FailOpenCount,
+	}
+}
+
+// Snapshot returns the checker's current revocation metrics, which makes
+// it a revocationMetricsProvider for the trust summary.
+func (c *ocspCrlRevocationChecker) Snapshot() map[string]int64 {
+	return c.metrics.Snapshot()
+}
+
+type cachedRevocationEntry struct {
+	status     RevocationStatus
+	nextUpdate time.Time
+}
+
+// ocspCrlRevocationChecker is the built-in RevocationChecker, backed by
+// OCSP with a CRL fallback and a cache keyed by certificate serial
+// number, honouring each response's nextUpdate as its TTL.
+type ocspCrlRevocationChecker struct {
+	mode    string
+	client  *http.Client
+	cache   map[string]cachedRevocationEntry
+	lock    sync.Mutex
+	metrics revocationMetrics
+}
+
+// NewRevocationChecker builds the default RevocationChecker, configured
+// via the ISHARE_REVOCATION_MODE env var.
+func NewRevocationChecker() *ocspCrlRevocationChecker {
+	mode := os.Getenv(RevocationModeEnvVar)
+	if mode != RevocationModeHardFail {
+		mode = RevocationModeSoftFail
+	}
+	return &ocspCrlRevocationChecker{
+		mode:   mode,
+		client: globalHttpClient,
+		cache:  map[string]cachedRevocationEntry{},
+	}
+}
+
+func (c *ocspCrlRevocationChecker) Check(ctx context.Context, certificate *x509.Certificate, issuer *x509.Certificate) (RevocationStatus, error) {
+	cacheKey := certificate.SerialNumber.String()
+
+	c.lock.Lock()
+	entry, found := c.cache[cacheKey]
+	c.lock.Unlock()
+	if found && time.Now().Before(entry.nextUpdate) {
+		c.metrics.recordCacheHit()
+		return entry.status, nil
+	}
+	c.metrics.recordCacheMiss()
+
+	status, nextUpdate, err := c.checkOcsp(ctx, certificate, issuer)
+	if err != nil {
+		logger.Warnf("OCSP check failed for %s, falling back to CRL. Err: %v", certificate.Subject, err)
+		status, nextUpdate, err = c.checkCrl(ctx, certificate, issuer)
+	}
+	if err != nil {
+		if c.mode == RevocationModeSoftFail {
+			logger.Warnf("Was not able to determine the revocation status for %s, failing open. Err: %v", certificate.Subject, err)
+			c.metrics.recordFailOpen()
+			return RevocationStatusGood, nil
+		}
+		return RevocationStatusUnknown, err
+	}
+
+	c.lock.Lock()
+	c.cache[cacheKey] = cachedRevocationEntry{status: status, nextUpdate: nextUpdate}
+	c.lock.Unlock()
+
+	return status, nil
+}
+
+func (c *ocspCrlRevocationChecker) checkOcsp(ctx context.Context, certificate *x509.Certificate, issuer *x509.Certificate) (RevocationStatus, time.Time, error) {
+	if len(certificate.OCSPServer) == 0 {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("certificate has no OCSP responder configured")
+	}
+
+	requestBytes, err := ocsp.CreateRequest(certificate, issuer, nil)
+	if err != nil {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("was not able to build the OCSP request: %w", err)
+	}
+
+	start := time.Now()
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", certificate.OCSPServer[0], bytes.NewReader(requestBytes))
+	if err != nil {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("was not able to build the OCSP http request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/ocsp-request")
+	httpResponse, err := c.client.Do(httpRequest)
+	c.metrics.recordOcspRequest(time.Since(start))
+	if err != nil {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("was not able to reach the OCSP responder: %w", err)
+	}
+	defer httpResponse.Body.Close()
+
+	ocspResponse, err := ocsp.ParseResponseForCert(bodyBytes(httpResponse.Body), certificate, issuer)
+	if err != nil {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("was not able to parse the OCSP response: %w", err)
+	}
+
+	if ocspResponse.Status == ocsp.Revoked {
+		return RevocationStatusRevoked, ocspResponse.NextUpdate, nil
+	}
+	return RevocationStatusGood, ocspResponse.NextUpdate, nil
+}
+
+func (c *ocspCrlRevocationChecker) checkCrl(ctx context.Context, certificate *x509.Certificate, issuer *x509.Certificate) (RevocationStatus, time.Time, error) {
+	if len(certificate.CRLDistributionPoints) == 0 {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("certificate has no CRL distribution point configured")
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", certificate.CRLDistributionPoints[0], nil)
+	if err != nil {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("was not able to build the CRL request: %w", err)
+	}
+	httpResponse, err := c.client.Do(httpRequest)
+	if err != nil {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("was not able to fetch the CRL: %w", err)
+	}
+	defer httpResponse.Body.Close()
+
+	crl, err := x509.ParseRevocationList(bodyBytes(httpResponse.Body))
+	if err != nil {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("was not able to parse the CRL: %w", err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("the CRL signature is not valid for the configured issuer: %w", err)
+	}
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		return RevocationStatusUnknown, time.Time{}, fmt.Errorf("the CRL is stale, its nextUpdate was %s", crl.NextUpdate)
+	}
+
+	for _, revoked := range crl.RevokedCertificateEntries {
+		if revoked.SerialNumber.Cmp(certificate.SerialNumber) == 0 {
+			return RevocationStatusRevoked, crl.NextUpdate, nil
+		}
+	}
+	return RevocationStatusGood, crl.NextUpdate, nil
+}
+
+// bodyBytes reads an http response body, returning it as a byte slice so
+// it can be handed to the ocsp/x509 parsers.
+func bodyBytes(body io.ReadCloser) []byte {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,77 @@
+package ishare
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// fakeRevocationChecker lets the isRevoked tests control the
+// RevocationChecker outcome directly, without standing up an OCSP
+// responder or CRL endpoint.
+type fakeRevocationChecker struct {
+	status RevocationStatus
+	err    error
+}
+
+func (f fakeRevocationChecker) Check(ctx context.Context, certificate *x509.Certificate, issuer *x509.Certificate) (RevocationStatus, error) {
+	return f.status, f.err
+}
+
+func TestIsRevokedGood(t *testing.T) {
+	repo := IShareTrustedParticipantRepository{revocationChecker: fakeRevocationChecker{status: RevocationStatusGood}}
+	if repo.isRevoked(&x509.Certificate{}, &x509.Certificate{}) {
+		t.Error("expected a good status to not be treated as revoked")
+	}
+}
+
+func TestIsRevokedRevoked(t *testing.T) {
+	repo := IShareTrustedParticipantRepository{revocationChecker: fakeRevocationChecker{status: RevocationStatusRevoked}}
+	if !repo.isRevoked(&x509.Certificate{}, &x509.Certificate{}) {
+		t.Error("expected a revoked status to be treated as revoked")
+	}
+}
+
+// TestIsRevokedFailsClosedOnError covers the RevocationModeHardFail path:
+// Check returns (RevocationStatusUnknown, err) whenever the status could
+// not be determined in hardfail mode, and isRevoked must fail closed -
+// i.e. deny the request - rather than let it through.
+func TestIsRevokedFailsClosedOnError(t *testing.T) {
+	repo := IShareTrustedParticipantRepository{
+		revocationChecker: fakeRevocationChecker{status: RevocationStatusUnknown, err: fmt.Errorf("OCSP responder unreachable")},
+	}
+	if !repo.isRevoked(&x509.Certificate{}, &x509.Certificate{}) {
+		t.Error("expected an undeterminable revocation status in hardfail mode to fail closed (be treated as revoked)")
+	}
+}
+
+func TestIsRevokedNoCheckerConfigured(t *testing.T) {
+	repo := IShareTrustedParticipantRepository{}
+	if repo.isRevoked(&x509.Certificate{}, &x509.Certificate{}) {
+		t.Error("expected no configured RevocationChecker to mean revocation checking is disabled, not a denial")
+	}
+}
+
+func TestRevocationCheckerCachesUntilNextUpdate(t *testing.T) {
+	checker := NewRevocationChecker()
+	cert := &x509.Certificate{SerialNumber: big.NewInt(1)}
+
+	checker.cache[cert.SerialNumber.String()] = cachedRevocationEntry{
+		status:     RevocationStatusGood,
+		nextUpdate: time.Now().Add(time.Hour),
+	}
+
+	status, err := checker.Check(context.Background(), cert, cert)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != RevocationStatusGood {
+		t.Errorf("expected the cached status to be returned, got %v", status)
+	}
+	if checker.metrics.CacheHits != 1 {
+		t.Errorf("expected the cache hit to be recorded, got %d", checker.metrics.CacheHits)
+	}
+}
@@ -0,0 +1,99 @@
+package ishare
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// CaSummary is the startup/debug view of a single trusted CA: what we
+// actually trust, not just what was configured.
+type CaSummary struct {
+	Fingerprint string `json:"fingerprint"`
+	SubjectDN   string `json:"subjectDn,omitempty"`
+	NotAfter    string `json:"notAfter,omitempty"`
+}
+
+// TrustSummary is a structured, one-shot report of everything a running
+// PDP trusts and signs with, so operators can diff it against what they
+// configured instead of digging through DEBUG logs.
+type TrustSummary struct {
+	SatelliteURL          string           `json:"satelliteUrl"`
+	SatelliteEORI         string           `json:"satelliteEori"`
+	TrustedCAs            []CaSummary      `json:"trustedCas"`
+	SignerKeyURI          string           `json:"signerKeyUri,omitempty"`
+	SignerKeyThumbprint   string           `json:"signerKeyThumbprint,omitempty"`
+	TrustedListRefreshInS int              `json:"trustedListRefreshIntervalSeconds"`
+	RevocationMetrics     map[string]int64 `json:"revocationMetrics,omitempty"`
+}
+
+// revocationMetricsProvider is implemented by ocspCrlRevocationChecker.
+// It is kept separate from the RevocationChecker interface so a custom
+// checker is not forced to expose metrics.
+type revocationMetricsProvider interface {
+	Snapshot() map[string]int64
+}
+
+// Summary builds the current TrustSummary for icr and logs it once at
+// INFO level, mirroring what step-ca logs about its X.509 root
+// fingerprint and CA keys at boot.
+func (icr IShareTrustedParticipantRepository) Summary(ctx context.Context, updateRateInS int) TrustSummary {
+	summary := TrustSummary{
+		SatelliteURL:          icr.satelliteAr.Host,
+		SatelliteEORI:         icr.satelliteAr.Id,
+		TrustedListRefreshInS: updateRateInS,
+	}
+
+	for _, fingerprint := range icr.trustedFingerprints {
+		summary.TrustedCAs = append(summary.TrustedCAs, describeTrustedCa(fingerprint))
+	}
+
+	if provider, ok := icr.revocationChecker.(revocationMetricsProvider); ok {
+		summary.RevocationMetrics = provider.Snapshot()
+	}
+
+	keyURI := ConfiguredKeyURI()
+	if keyURI != "" {
+		summary.SignerKeyURI = string(keyURI)
+		if signer, err := Signer(ctx, keyURI); err != nil {
+			logger.Warnf("Was not able to resolve the signer for the startup summary. Err: %v", err)
+		} else if thumbprint, err := publicKeyThumbprint(signer.Public()); err != nil {
+			logger.Warnf("Was not able to compute the signer key thumbprint. Err: %v", err)
+		} else {
+			summary.SignerKeyThumbprint = thumbprint
+		}
+	}
+
+	logger.Infof("Startup trust summary: %+v", summary)
+	return summary
+}
+
+// describeTrustedCa looks up the subject DN and notAfter for a trusted
+// CA fingerprint from the cache populated by resolveTrustedCaCertificates.
+// A fingerprint whose certificate was never resolved (e.g. no
+// ISHARE_TRUSTED_CA_CERTIFICATES_PATH is configured) is reported with
+// just the fingerprint.
+func describeTrustedCa(fingerprint string) CaSummary {
+	if cert, found := trustedCaCertificateCache.get(fingerprint); found {
+		return CaSummary{
+			Fingerprint: fingerprint,
+			SubjectDN:   cert.Subject.String(),
+			NotAfter:    cert.NotAfter.Format(time.RFC3339),
+		}
+	}
+	return CaSummary{Fingerprint: fingerprint}
+}
+
+// publicKeyThumbprint returns the SHA-256 thumbprint of a public key's
+// SubjectPublicKeyInfo encoding, in the same hex notation used for
+// certificate fingerprints elsewhere in this package.
+func publicKeyThumbprint(publicKey any) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("was not able to marshal the public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return fmt.Sprintf("%X", sum[:]), nil
+}
@@ -0,0 +1,62 @@
+package ishare
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"testing"
+)
+
+func TestDescribeTrustedCaWithCachedCertificate(t *testing.T) {
+	_, cert := generateSelfSignedWithSubject(t, pkix.Name{CommonName: "Cached CA"}, true)
+	fingerprint := buildCertificateFingerprint(cert)
+	trustedCaCertificateCache.put(fingerprint, cert)
+
+	summary := describeTrustedCa(fingerprint)
+	if summary.Fingerprint != fingerprint {
+		t.Errorf("expected fingerprint %q, got %q", fingerprint, summary.Fingerprint)
+	}
+	if summary.SubjectDN != cert.Subject.String() {
+		t.Errorf("expected subject DN %q, got %q", cert.Subject.String(), summary.SubjectDN)
+	}
+	if summary.NotAfter == "" {
+		t.Error("expected notAfter to be populated for a cached certificate")
+	}
+}
+
+func TestDescribeTrustedCaWithoutCachedCertificate(t *testing.T) {
+	summary := describeTrustedCa("unresolved-fingerprint")
+	if summary.Fingerprint != "unresolved-fingerprint" {
+		t.Errorf("expected the bare fingerprint to be reported, got %q", summary.Fingerprint)
+	}
+	if summary.SubjectDN != "" || summary.NotAfter != "" {
+		t.Error("expected no subject DN or notAfter for an unresolved fingerprint")
+	}
+}
+
+func TestPublicKeyThumbprintIsStableAndDistinct(t *testing.T) {
+	first, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("was not able to generate a test key: %v", err)
+	}
+	second, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("was not able to generate a test key: %v", err)
+	}
+
+	firstThumbprint, err := publicKeyThumbprint(&first.PublicKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, err := publicKeyThumbprint(&first.PublicKey); err != nil || again != firstThumbprint {
+		t.Error("expected the thumbprint of the same public key to be stable across calls")
+	}
+	secondThumbprint, err := publicKeyThumbprint(&second.PublicKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if firstThumbprint == secondThumbprint {
+		t.Error("expected different public keys to produce different thumbprints")
+	}
+}